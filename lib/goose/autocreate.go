@@ -0,0 +1,199 @@
+package goose
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// autoCreator is implemented by dialects that can detect and recover from
+// a missing-database error. Dialects that don't implement it (Sqlite3Dialect)
+// are a no-op for auto-create.
+type autoCreator interface {
+	isMissingDatabaseErr(err error) bool
+	// createDatabase creates the target database via maintenanceDB,
+	// returning its name. Idempotent: already-exists is not an error.
+	createDatabase(openStr, maintenanceDB string) (dbname string, err error)
+	// defaultMaintenanceDB is used when DBConf.MaintenanceDB isn't set.
+	defaultMaintenanceDB() string
+}
+
+// autoCreateDatabase creates conf's target database via its dialect's
+// autoCreator, if it implements one.
+func autoCreateDatabase(conf *DBConf) error {
+	creator, ok := conf.Driver.Dialect.(autoCreator)
+	if !ok {
+		return nil
+	}
+
+	maintenanceDB := conf.MaintenanceDB
+	if maintenanceDB == "" {
+		maintenanceDB = creator.defaultMaintenanceDB()
+	}
+
+	conf.logger().Printf("goose: database does not exist, attempting to create it")
+
+	dbname, err := creator.createDatabase(conf.Driver.OpenStr, maintenanceDB)
+	if err != nil {
+		return fmt.Errorf("auto-create database: %w", err)
+	}
+
+	conf.logger().Printf("goose: created database %q", dbname)
+	return nil
+}
+
+func (pg PostgresDialect) isMissingDatabaseErr(err error) bool {
+	pgErr, ok := err.(*pq.Error)
+	return ok && pgErr.Code == "3D000"
+}
+
+func (pg PostgresDialect) defaultMaintenanceDB() string {
+	return "postgres"
+}
+
+func (pg PostgresDialect) createDatabase(openStr, maintenanceDB string) (string, error) {
+	// OpenStr may still be a raw postgres:// URL if the DBConf was built
+	// by hand rather than via NewDBConf/NewDBConfFromDSN.
+	if parsedURL, err := pq.ParseURL(openStr); err == nil && parsedURL != "" {
+		openStr = parsedURL
+	}
+
+	params := parseLibpqParams(openStr)
+
+	dbname := params["dbname"]
+	if dbname == "" {
+		return "", errors.New("can't auto-create database: no dbname in DSN")
+	}
+
+	maintenanceParams := make(map[string]string, len(params))
+	for k, v := range params {
+		maintenanceParams[k] = v
+	}
+	maintenanceParams["dbname"] = maintenanceDB
+
+	mdb, err := sql.Open("postgres", buildLibpqDSN(maintenanceParams))
+	if err != nil {
+		return "", err
+	}
+	defer mdb.Close()
+
+	_, err = mdb.Exec(fmt.Sprintf("CREATE DATABASE %s", pq.QuoteIdentifier(dbname)))
+	if isAlreadyExistsErr(err) {
+		return dbname, nil
+	}
+	return dbname, err
+}
+
+// isAlreadyExistsErr reports whether err is Postgres's "database already
+// exists" error, so createDatabase can treat it as a no-op.
+func isAlreadyExistsErr(err error) bool {
+	pgErr, ok := err.(*pq.Error)
+	return ok && pgErr.Code == "42P04"
+}
+
+func (m MySqlDialect) isMissingDatabaseErr(err error) bool {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	return ok && mysqlErr.Number == 1049
+}
+
+func (m MySqlDialect) defaultMaintenanceDB() string {
+	// connecting without selecting a database is enough to issue
+	// CREATE DATABASE against the server.
+	return ""
+}
+
+func (m MySqlDialect) createDatabase(openStr, maintenanceDB string) (string, error) {
+	cfg, err := mysql.ParseDSN(openStr)
+	if err != nil {
+		return "", err
+	}
+
+	dbname := cfg.DBName
+	if dbname == "" {
+		return "", errors.New("can't auto-create database: no dbname in DSN")
+	}
+
+	maintenanceCfg := *cfg
+	maintenanceCfg.DBName = maintenanceDB
+
+	mdb, err := sql.Open("mysql", maintenanceCfg.FormatDSN())
+	if err != nil {
+		return "", err
+	}
+	defer mdb.Close()
+
+	quoted := "`" + strings.ReplaceAll(dbname, "`", "``") + "`"
+	_, err = mdb.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", quoted))
+	return dbname, err
+}
+
+// parseLibpqParams tokenizes a libpq key=value connection string, e.g.
+// `dbname=foo user=bar password='a b'`, into a name -> value map.
+func parseLibpqParams(s string) map[string]string {
+	params := map[string]string{}
+
+	i, n := 0, len(s)
+	for i < n {
+		for i < n && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n') {
+			i++
+		}
+		if i >= n {
+			break
+		}
+
+		keyStart := i
+		for i < n && s[i] != '=' {
+			i++
+		}
+		if i >= n {
+			break
+		}
+		key := s[keyStart:i]
+		i++ // skip '='
+
+		var val strings.Builder
+		if i < n && s[i] == '\'' {
+			i++
+			for i < n && s[i] != '\'' {
+				if s[i] == '\\' && i+1 < n {
+					i++
+				}
+				val.WriteByte(s[i])
+				i++
+			}
+			i++ // skip closing quote
+		} else {
+			for i < n && s[i] != ' ' && s[i] != '\t' && s[i] != '\n' {
+				val.WriteByte(s[i])
+				i++
+			}
+		}
+
+		params[key] = val.String()
+	}
+
+	return params
+}
+
+// buildLibpqDSN is the inverse of parseLibpqParams: it renders a libpq
+// key=value connection string, quoting every value.
+func buildLibpqDSN(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s='%s'", k, replacer.Replace(params[k])))
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,128 @@
+package goose
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestParseLibpqParams(t *testing.T) {
+	params := parseLibpqParams(`dbname=foo user=bar password='a b\'c' host=localhost`)
+
+	want := map[string]string{
+		"dbname":   "foo",
+		"user":     "bar",
+		"password": "a b'c",
+		"host":     "localhost",
+	}
+	for k, v := range want {
+		if params[k] != v {
+			t.Errorf("params[%q] = %q, want %q", k, params[k], v)
+		}
+	}
+}
+
+func TestParseLibpqParamsMissingDbname(t *testing.T) {
+	params := parseLibpqParams("user=bar host=localhost")
+	if _, ok := params["dbname"]; ok {
+		t.Fatalf("expected no dbname, got %q", params["dbname"])
+	}
+}
+
+func TestBuildLibpqDSNRoundTrip(t *testing.T) {
+	in := map[string]string{
+		"dbname":   "weird db",
+		"password": `back\slash and 'quote`,
+	}
+
+	out := buildLibpqDSN(in)
+	got := parseLibpqParams(out)
+
+	for k, v := range in {
+		if got[k] != v {
+			t.Errorf("round trip: params[%q] = %q, want %q (dsn was %q)", k, got[k], v, out)
+		}
+	}
+}
+
+func TestPostgresDialectIsMissingDatabaseErr(t *testing.T) {
+	pg := PostgresDialect{}
+
+	if !pg.isMissingDatabaseErr(&pq.Error{Code: "3D000"}) {
+		t.Error("expected 3D000 to be a missing-database error")
+	}
+	if pg.isMissingDatabaseErr(&pq.Error{Code: "42P01"}) {
+		t.Error("expected a different pq error code not to match")
+	}
+	if pg.isMissingDatabaseErr(errors.New("boom")) {
+		t.Error("expected a non-pq error not to match")
+	}
+}
+
+func TestPostgresDialectCreateDatabaseRequiresDbname(t *testing.T) {
+	pg := PostgresDialect{}
+
+	if _, err := pg.createDatabase("user=bar host=localhost", "postgres"); err == nil {
+		t.Fatal("expected an error for a DSN with no dbname")
+	}
+
+	if _, err := pg.createDatabase("postgres://user@localhost/", "postgres"); err == nil {
+		t.Fatal("expected an error for a URL DSN with no dbname")
+	}
+}
+
+func TestPostgresDialectCreateDatabaseParsesURLForm(t *testing.T) {
+	pg := PostgresDialect{}
+
+	// a reachable dbname but an unreachable host: if the URL wasn't
+	// parsed into a dbname, we'd fail with "no dbname in DSN" instead.
+	_, err := pg.createDatabase("postgres://user@127.0.0.1:1/mydb", "postgres")
+	if err == nil || err.Error() == "can't auto-create database: no dbname in DSN" {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+}
+
+func TestIsAlreadyExistsErr(t *testing.T) {
+	if !isAlreadyExistsErr(&pq.Error{Code: "42P04"}) {
+		t.Error("expected 42P04 to be an already-exists error")
+	}
+	if isAlreadyExistsErr(&pq.Error{Code: "3D000"}) {
+		t.Error("expected a different pq error code not to match")
+	}
+	if isAlreadyExistsErr(errors.New("boom")) {
+		t.Error("expected a non-pq error not to match")
+	}
+}
+
+func TestMySqlDialectIsMissingDatabaseErr(t *testing.T) {
+	m := MySqlDialect{}
+
+	if !m.isMissingDatabaseErr(&mysql.MySQLError{Number: 1049}) {
+		t.Error("expected error 1049 to be a missing-database error")
+	}
+	if m.isMissingDatabaseErr(&mysql.MySQLError{Number: 1045}) {
+		t.Error("expected a different mysql error number not to match")
+	}
+	if m.isMissingDatabaseErr(errors.New("boom")) {
+		t.Error("expected a non-mysql error not to match")
+	}
+}
+
+func TestMySqlDialectCreateDatabaseRequiresDbname(t *testing.T) {
+	m := MySqlDialect{}
+
+	if _, err := m.createDatabase("user:pass@tcp(127.0.0.1:1)/", ""); err == nil {
+		t.Fatal("expected an error for a DSN with no dbname")
+	}
+}
+
+func TestMySqlDialectCreateDatabaseParsesDSN(t *testing.T) {
+	m := MySqlDialect{}
+
+	_, err := m.createDatabase("user:pass@tcp(127.0.0.1:1)/mydb", "")
+	if err == nil || err.Error() == "can't auto-create database: no dbname in DSN" {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package goose
+
+import (
+	"sync"
+
+	// registers the "sqlite3" database/sql driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DBDriver encapsulates the info needed to work with
+// a specific database driver
+type DBDriver struct {
+	Name    string
+	OpenStr string
+	Import  string
+	Dialect SqlDialect
+}
+
+// registeredDriver holds what RegisterDriver associated with a driver name.
+type registeredDriver struct {
+	importPath string
+	dialect    SqlDialect
+}
+
+var (
+	driversMu        sync.RWMutex
+	drivers          = map[string]registeredDriver{}
+	registerBuiltins sync.Once
+)
+
+// RegisterDriver makes a SqlDialect available under name, so that
+// "driver: name" in dbconf.yml resolves to it without forking goose.
+// Multiple names may share a dialect (e.g. "pgx" and "postgres"); see
+// SqlDialect.DefaultDriver.
+func RegisterDriver(name string, importPath string, dialect SqlDialect) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	drivers[name] = registeredDriver{
+		importPath: importPath,
+		dialect:    dialect,
+	}
+}
+
+// registerBuiltinDrivers seeds the registry with goose's own dialects,
+// without overwriting anything RegisterDriver already set.
+func registerBuiltinDrivers() {
+	builtins := []struct {
+		name       string
+		importPath string
+		dialect    SqlDialect
+	}{
+		{"postgres", "github.com/lib/pq", &PostgresDialect{}},
+		{"mysql", "github.com/go-sql-driver/mysql", &MySqlDialect{}},
+		{"sqlite3", "github.com/mattn/go-sqlite3", &Sqlite3Dialect{}},
+	}
+
+	for _, b := range builtins {
+		if _, ok := drivers[b.name]; !ok {
+			drivers[b.name] = registeredDriver{
+				importPath: b.importPath,
+				dialect:    b.dialect,
+			}
+		}
+	}
+}
+
+// ensureBuiltinDrivers registers the built-in dialects exactly once.
+func ensureBuiltinDrivers() {
+	registerBuiltins.Do(func() {
+		driversMu.Lock()
+		defer driversMu.Unlock()
+		registerBuiltinDrivers()
+	})
+}
+
+// Create a new DBDriver and populate driver specific
+// fields for drivers that we know about.
+// Further customization may be done in NewDBConf
+func newDBDriver(name, open string) DBDriver {
+	ensureBuiltinDrivers()
+
+	d := DBDriver{
+		Name:    name,
+		OpenStr: open,
+	}
+
+	driversMu.RLock()
+	reg, ok := drivers[name]
+	driversMu.RUnlock()
+
+	if ok {
+		d.Import = reg.importPath
+		d.Dialect = reg.dialect
+
+		// aliases (e.g. "pgx") still open through the dialect's own driver
+		if sqlDriver := reg.dialect.DefaultDriver(); sqlDriver != "" {
+			d.Name = sqlDriver
+		}
+	}
+
+	return d
+}
+
+// ensure we have enough info about this driver
+func (drv *DBDriver) IsValid() bool {
+	return len(drv.Import) > 0 && drv.Dialect != nil
+}
@@ -6,26 +6,38 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 
 	"github.com/kylelemons/go-gypsy/yaml"
 	"github.com/lib/pq"
 )
 
-// DBDriver encapsulates the info needed to work with
-// a specific database driver
-type DBDriver struct {
-	Name    string
-	OpenStr string
-	Import  string
-	Dialect SqlDialect
-}
+// AutoCreatePolicy controls whether the target database is auto-created.
+type AutoCreatePolicy int
+
+const (
+	AutoCreateOff       AutoCreatePolicy = iota // never create it
+	AutoCreateIfMissing                         // create it if Ping fails as missing
+	AutoCreateAlways                            // create it (idempotently) before every Open
+)
 
 type DBConf struct {
 	MigrationsDir string
 	Env           string
 	Driver        DBDriver
 	PgSchema      string
+
+	// AutoCreate defaults to AutoCreateOff; NewDBConf, NewDBConfFromDSN
+	// and NewDBConfFromEnv set it to AutoCreateIfMissing to match
+	// goose's historical behavior.
+	AutoCreate AutoCreatePolicy
+
+	// MaintenanceDB overrides the database to connect to when
+	// auto-creating (default "postgres" for Postgres).
+	MaintenanceDB string
+
+	// Logger receives auto-create progress messages, defaulting to
+	// log.Default().
+	Logger Logger
 }
 
 // extract configuration details from the given file
@@ -80,31 +92,57 @@ func NewDBConf(p, env string, pgschema string) (*DBConf, error) {
 		Env:           env,
 		Driver:        d,
 		PgSchema:      pgschema,
+		AutoCreate:    AutoCreateIfMissing,
 	}, nil
 }
 
-// Create a new DBDriver and populate driver specific
-// fields for drivers that we know about.
-// Further customization may be done in NewDBConf
-func newDBDriver(name, open string) DBDriver {
+// NewDBConfFromDSN builds a DBConf directly from a driver name and DSN,
+// without a dbconf.yml. dsn may be a postgres:// URL or a key=value
+// libpq string.
+func NewDBConfFromDSN(driver, dsn, pgschema string) (*DBConf, error) {
 
-	d := DBDriver{
-		Name:    name,
-		OpenStr: open,
+	if driver == "postgres" {
+		if parsedURL, err := pq.ParseURL(dsn); err == nil && parsedURL != "" {
+			dsn = parsedURL
+		}
 	}
 
-	switch name {
-	case "postgres":
-		d.Import = "github.com/lib/pq"
-		d.Dialect = &PostgresDialect{}
+	d := newDBDriver(driver, dsn)
+
+	if !d.IsValid() {
+		return nil, errors.New(fmt.Sprintf("Invalid DBConf: %v", d))
 	}
 
-	return d
+	return &DBConf{
+		Driver:     d,
+		PgSchema:   pgschema,
+		AutoCreate: AutoCreateIfMissing,
+	}, nil
 }
 
-// ensure we have enough info about this driver
-func (drv *DBDriver) IsValid() bool {
-	return len(drv.Import) > 0 && drv.Dialect != nil
+// NewDBConfFromEnv builds a DBConf from environment variables prefixed
+// with prefix, e.g. "GOOSE_" reads GOOSE_DRIVER, GOOSE_DBSTRING,
+// GOOSE_MIGRATION_DIR and GOOSE_PG_SCHEMA.
+func NewDBConfFromEnv(prefix string) (*DBConf, error) {
+
+	driver := os.Getenv(prefix + "DRIVER")
+	if driver == "" {
+		return nil, errors.New(fmt.Sprintf("%sDRIVER is not set", prefix))
+	}
+
+	dsn := os.Getenv(prefix + "DBSTRING")
+	if dsn == "" {
+		return nil, errors.New(fmt.Sprintf("%sDBSTRING is not set", prefix))
+	}
+
+	conf, err := NewDBConfFromDSN(driver, dsn, os.Getenv(prefix+"PG_SCHEMA"))
+	if err != nil {
+		return nil, err
+	}
+
+	conf.MigrationsDir = os.Getenv(prefix + "MIGRATION_DIR")
+
+	return conf, nil
 }
 
 // OpenDBFromDBConf wraps database/sql.DB.Open() and configures
@@ -112,40 +150,52 @@ func (drv *DBDriver) IsValid() bool {
 //
 // Callers must Close() the returned DB.
 func OpenDBFromDBConf(conf *DBConf) (*sql.DB, error) {
-	db, err := sql.Open(conf.Driver.Name, conf.Driver.OpenStr)
+	// sqlite3 DSNs are filesystem paths; sql.Open won't create the
+	// containing directory, so do it ourselves.
+	if conf.Driver.Name == "sqlite3" {
+		if dir := filepath.Dir(conf.Driver.OpenStr); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if conf.AutoCreate == AutoCreateAlways {
+		if err := autoCreateDatabase(conf); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := openDB(conf)
 	if err != nil {
 		return nil, err
 	}
-	err = db.Ping()
-	if pg_err, ok := err.(*pq.Error); ok {
-		if pg_err.Code == "3D000" {
-			fmt.Println("Database does not exist. Trying to create it.")
-			regex := regexp.MustCompile("dbname=([^ ]+)")
-			if m := regex.FindStringSubmatch(conf.Driver.OpenStr); m != nil && len(m) == 2 {
-				dbname := m[1]
-				masterConnection := regex.ReplaceAllLiteralString(conf.Driver.OpenStr, "dbname=postgres")
-				dbm, err := sql.Open(conf.Driver.Name, masterConnection)
-				if err != nil {
-					return nil, err
-				}
-				defer dbm.Close()
-				if _, err = dbm.Exec(fmt.Sprintf("CREATE DATABASE %s", dbname)); err != nil {
-					return nil, err
-				}
-				//retry to connecto to the now created database
-				db, err = sql.Open(conf.Driver.Name, conf.Driver.OpenStr)
-			} else {
-				return nil, errors.New("Can't create database with unknown name")
-			}
+
+	if err := db.Ping(); err != nil {
+		creator, ok := conf.Driver.Dialect.(autoCreator)
+		if conf.AutoCreate != AutoCreateIfMissing || !ok || !creator.isMissingDatabaseErr(err) {
+			return nil, err
 		}
-	}
 
-	// if a postgres schema has been specified, apply it
-	if conf.Driver.Name == "postgres" && conf.PgSchema != "" {
-		if _, err := db.Exec("SET search_path TO " + conf.PgSchema); err != nil {
+		if err := autoCreateDatabase(conf); err != nil {
+			return nil, err
+		}
+
+		db, err = openDB(conf)
+		if err != nil {
 			return nil, err
 		}
 	}
 
 	return db, nil
 }
+
+// openDB opens conf's target database, applying a postgres schema
+// per-connection via a wrapper connector if one is set.
+func openDB(conf *DBConf) (*sql.DB, error) {
+	if conf.Driver.Name == "postgres" && conf.PgSchema != "" {
+		return openPostgresSchemaDB(conf.Driver.OpenStr, conf.PgSchema), nil
+	}
+
+	return sql.Open(conf.Driver.Name, conf.Driver.OpenStr)
+}
@@ -0,0 +1,126 @@
+package goose
+
+import (
+	"database/sql"
+)
+
+// SqlDialect abstracts the details of specific SQL dialects
+// for goose's few SQL specific statements
+type SqlDialect interface {
+	createVersionTableSql() string // sql string to create the db version table
+	insertVersionSql() string      // sql string to insert the initial version table row
+	dbVersionQuery(db *sql.DB) (*sql.Rows, error)
+
+	// DefaultDriver is the database/sql driver name to open this dialect
+	// with, e.g. "postgres" for both "pgx" and "postgres" aliases.
+	DefaultDriver() string
+}
+
+// dialectByName looks up a dialect by the name used in dbconf.yml.
+func dialectByName(d string) SqlDialect {
+	ensureBuiltinDrivers()
+
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	if reg, ok := drivers[d]; ok {
+		return reg.dialect
+	}
+
+	return nil
+}
+
+////////////////
+// Postgres
+////////////////
+
+type PostgresDialect struct{}
+
+func (pg PostgresDialect) createVersionTableSql() string {
+	return `CREATE TABLE goose_db_version (
+            	id serial NOT NULL,
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`
+}
+
+func (pg PostgresDialect) insertVersionSql() string {
+	return "INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, $2);"
+}
+
+func (pg PostgresDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	rows, err := db.Query("SELECT version_id, is_applied from goose_db_version ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	return rows, err
+}
+
+func (pg PostgresDialect) DefaultDriver() string {
+	return "postgres"
+}
+
+////////////////
+// MySQL
+////////////////
+
+type MySqlDialect struct{}
+
+func (m MySqlDialect) createVersionTableSql() string {
+	return `CREATE TABLE goose_db_version (
+                id int NOT NULL AUTO_INCREMENT,
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`
+}
+
+func (m MySqlDialect) insertVersionSql() string {
+	return "INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, ?);"
+}
+
+func (m MySqlDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	rows, err := db.Query("SELECT version_id, is_applied from goose_db_version ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	return rows, err
+}
+
+func (m MySqlDialect) DefaultDriver() string {
+	return "mysql"
+}
+
+////////////////
+// sqlite3
+////////////////
+
+type Sqlite3Dialect struct{}
+
+func (m Sqlite3Dialect) createVersionTableSql() string {
+	return `CREATE TABLE goose_db_version (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                version_id INTEGER NOT NULL,
+                is_applied INTEGER NOT NULL,
+                tstamp TIMESTAMP DEFAULT (datetime('now'))
+            );`
+}
+
+func (m Sqlite3Dialect) insertVersionSql() string {
+	return "INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, ?);"
+}
+
+func (m Sqlite3Dialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	rows, err := db.Query("SELECT version_id, is_applied from goose_db_version ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	return rows, err
+}
+
+func (m Sqlite3Dialect) DefaultDriver() string {
+	return "sqlite3"
+}
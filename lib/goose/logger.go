@@ -0,0 +1,16 @@
+package goose
+
+import "log"
+
+// Logger is the logging interface goose needs. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// logger returns conf.Logger, defaulting to log.Default().
+func (conf *DBConf) logger() Logger {
+	if conf.Logger != nil {
+		return conf.Logger
+	}
+	return log.Default()
+}
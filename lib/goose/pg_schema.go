@@ -0,0 +1,50 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/lib/pq"
+)
+
+// postgresSchemaConnector wraps lib/pq's driver and pins search_path on
+// every connection it opens. Used via sql.OpenDB, not sql.Register, so a
+// PgSchema never leaks a driver into the global registry.
+type postgresSchemaConnector struct {
+	dsn    string
+	schema string
+}
+
+func (c postgresSchemaConnector) Connect(_ context.Context) (driver.Conn, error) {
+	conn, err := pq.Driver{}.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.Execer)
+	if !ok {
+		return conn, nil
+	}
+
+	_, err = execer.Exec(
+		"SELECT set_config('search_path', $1 || ',' || current_setting('search_path'), false)",
+		[]driver.Value{c.schema},
+	)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (c postgresSchemaConnector) Driver() driver.Driver {
+	return pq.Driver{}
+}
+
+// openPostgresSchemaDB opens dsn with search_path pinned to schema on
+// every connection the pool creates.
+func openPostgresSchemaDB(dsn, schema string) *sql.DB {
+	return sql.OpenDB(postgresSchemaConnector{dsn: dsn, schema: schema})
+}